@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/src-d/sourced-ce/cmd/sourced/compose"
+)
+
+type statusCmd struct {
+	Command `name:"status" short-description:"Show a live dashboard of source{d}'s containers." long-description:"Show a live dashboard of source{d}'s containers: service, state, health, uptime, last event and exposed ports. Exits on Ctrl-C."`
+
+	JSON bool `long:"json" description:"Print the current status as JSON once, instead of the live table."`
+}
+
+func (c *statusCmd) Execute(args []string) error {
+	engine, err := compose.NewEngine(projectName())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	services, err := engine.ListServices(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot list services")
+	}
+
+	events, err := compose.NewMonitor(engine).Subscribe(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot subscribe to service events")
+	}
+
+	if c.JSON {
+		return printStatusOnce(services, events)
+	}
+
+	return printStatusLive(ctx, events)
+}
+
+func init() {
+	rootCmd.AddCommand(&statusCmd{})
+}
+
+// statusRow is a single row of the `sourced status` dashboard.
+type statusRow struct {
+	Service   string    `json:"service"`
+	State     string    `json:"state"`
+	Health    string    `json:"health,omitempty"`
+	Since     time.Time `json:"since,omitempty"`
+	LastEvent string    `json:"last_event"`
+	Ports     []string  `json:"ports,omitempty"`
+}
+
+func applyStatusEvent(rows map[string]*statusRow, ev compose.ServiceEvent) {
+	row, ok := rows[ev.Service]
+	if !ok {
+		row = &statusRow{Service: ev.Service}
+		rows[ev.Service] = row
+	}
+
+	row.State = ev.State.Status
+	row.Health = ev.State.Health
+	row.Since = ev.State.StartedAt
+	row.LastEvent = string(ev.Kind)
+	row.Ports = ev.State.Ports
+}
+
+func sortedStatusRows(rows map[string]*statusRow) []*statusRow {
+	out := make([]*statusRow, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, row)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Service < out[j].Service })
+
+	return out
+}
+
+// statusOnceTimeout bounds how long printStatusOnce waits for every known
+// service's initial snapshot. Without it, printStatusOnce would block
+// forever whenever a service never gets a row: the monitor skips a service
+// whose state it cannot read, and its own, independent ListServices call
+// does not see a container that disappeared after the one Execute made.
+const statusOnceTimeout = 5 * time.Second
+
+// printStatusOnce waits for an initial snapshot of every known service and
+// prints it as a single JSON array, for scripting. It gives up and prints
+// whatever it has, including an empty array, once statusOnceTimeout
+// elapses or services is empty to begin with.
+func printStatusOnce(services []string, events <-chan compose.ServiceEvent) error {
+	rows := map[string]*statusRow{}
+
+	if len(services) > 0 {
+		deadline := time.NewTimer(statusOnceTimeout)
+		defer deadline.Stop()
+
+	collect:
+		for len(rows) < len(services) {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					break collect
+				}
+
+				applyStatusEvent(rows, ev)
+			case <-deadline.C:
+				break collect
+			}
+		}
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(sortedStatusRows(rows))
+}
+
+// printStatusLive renders a table of every service's status, redrawing it
+// in place as events arrive from the compose.Monitor, until the user
+// interrupts it with Ctrl-C.
+func printStatusLive(ctx context.Context, events <-chan compose.ServiceEvent) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	charset := spinnerCharset()
+	rows := map[string]*statusRow{}
+	linesPrinted := 0
+	frame := 0
+
+	redraw := func() {
+		lines := renderStatusTable(sortedStatusRows(rows), charset[frame%len(charset)])
+
+		// cmd.exe's legacy console doesn't support ANSI cursor movement,
+		// the same reason spinnerCharset falls back to plain ASCII on
+		// Windows: rather than render garbage escape sequences, just print
+		// each frame fresh and let it scroll.
+		if runtime.GOOS != "windows" {
+			for i := 0; i < linesPrinted; i++ {
+				fmt.Print("\033[F\033[K")
+			}
+		}
+
+		fmt.Println(strings.Join(lines, "\n"))
+		linesPrinted = len(lines)
+	}
+
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-interrupt:
+			return nil
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			applyStatusEvent(rows, ev)
+			redraw()
+		case <-ticker.C:
+			frame++
+			redraw()
+		}
+	}
+}
+
+// renderStatusTable renders rows as a fixed-width table, using spin as the
+// "still initializing" indicator for services with no known state yet.
+func renderStatusTable(rows []*statusRow, spin int) []string {
+	lines := []string{
+		fmt.Sprintf("%-20s %-12s %-10s %-10s %-14s %s",
+			"SERVICE", "STATE", "HEALTH", "UPTIME", "LAST EVENT", "PORTS"),
+	}
+
+	for _, row := range rows {
+		state := row.State
+		if state == "" || state == "created" {
+			state = string(spin)
+		}
+
+		health := row.Health
+		if health == "" {
+			health = "-"
+		}
+
+		uptime := "-"
+		if !row.Since.IsZero() {
+			uptime = time.Since(row.Since).Truncate(time.Second).String()
+		}
+
+		ports := strings.Join(row.Ports, ", ")
+		if ports == "" {
+			ports = "-"
+		}
+
+		lines = append(lines, fmt.Sprintf("%-20s %-12s %-10s %-10s %-14s %s",
+			row.Service, state, health, uptime, row.LastEvent, ports))
+	}
+
+	return lines
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/src-d/sourced-ce/cmd/sourced/compose"
+)
+
+func TestApplyStatusEvent(t *testing.T) {
+	rows := map[string]*statusRow{}
+	since := time.Now()
+
+	applyStatusEvent(rows, compose.ServiceEvent{
+		Service: "gitcollector",
+		Kind:    compose.ServiceStarted,
+		State: compose.ServiceState{
+			Status:    "running",
+			Health:    "healthy",
+			StartedAt: since,
+			Ports:     []string{"127.0.0.1:8080->8080/tcp"},
+		},
+	})
+
+	row, ok := rows["gitcollector"]
+	if !ok {
+		t.Fatal("applyStatusEvent() did not create a row for the service")
+	}
+
+	if row.State != "running" || row.Health != "healthy" || row.LastEvent != string(compose.ServiceStarted) {
+		t.Errorf("applyStatusEvent() row = %+v", row)
+	}
+
+	if len(row.Ports) != 1 || row.Ports[0] != "127.0.0.1:8080->8080/tcp" {
+		t.Errorf("applyStatusEvent() ports = %v", row.Ports)
+	}
+
+	// A later event for the same service updates the row in place instead
+	// of creating a new one.
+	applyStatusEvent(rows, compose.ServiceEvent{
+		Service: "gitcollector",
+		Kind:    compose.ServiceExitedEvent,
+		State:   compose.ServiceState{Status: "exited"},
+	})
+
+	if len(rows) != 1 {
+		t.Fatalf("len(rows) = %d, want 1", len(rows))
+	}
+
+	if rows["gitcollector"].State != "exited" {
+		t.Errorf("State = %q, want exited", rows["gitcollector"].State)
+	}
+}
+
+func TestSortedStatusRows(t *testing.T) {
+	rows := map[string]*statusRow{
+		"sourced-ui":   {Service: "sourced-ui"},
+		"gitcollector": {Service: "gitcollector"},
+		"ghsync":       {Service: "ghsync"},
+	}
+
+	sorted := sortedStatusRows(rows)
+	if len(sorted) != 3 {
+		t.Fatalf("len(sorted) = %d, want 3", len(sorted))
+	}
+
+	want := []string{"ghsync", "gitcollector", "sourced-ui"}
+	for i, service := range want {
+		if sorted[i].Service != service {
+			t.Errorf("sorted[%d].Service = %q, want %q", i, sorted[i].Service, service)
+		}
+	}
+}
+
+func TestPrintStatusOnceNoServices(t *testing.T) {
+	events := make(chan compose.ServiceEvent)
+	defer close(events)
+
+	done := make(chan error, 1)
+	go func() { done <- printStatusOnce(nil, events) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("printStatusOnce() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("printStatusOnce() blocked with zero services")
+	}
+}
+
+func TestPrintStatusOnceClosedChannel(t *testing.T) {
+	events := make(chan compose.ServiceEvent)
+	close(events)
+
+	out := captureStdout(t, func() {
+		if err := printStatusOnce([]string{"sourced-ui"}, events); err != nil {
+			t.Fatalf("printStatusOnce() error = %v", err)
+		}
+	})
+
+	var rows []statusRow
+	if err := json.Unmarshal(out, &rows); err != nil {
+		t.Fatalf("output is not valid JSON: %v (%s)", err, out)
+	}
+
+	if len(rows) != 0 {
+		t.Errorf("len(rows) = %d, want 0 once the events channel closes early", len(rows))
+	}
+}
+
+// captureStdout runs fn with os.Stdout redirected, and returns what it wrote.
+func captureStdout(t *testing.T, fn func()) []byte {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe() error = %v", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+
+	w.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	return out
+}
+
+func TestRenderStatusTable(t *testing.T) {
+	rows := []*statusRow{
+		{Service: "sourced-ui", State: "running", Health: "healthy", LastEvent: "start"},
+		{Service: "gitcollector", State: "", LastEvent: "snapshot"},
+	}
+
+	lines := renderStatusTable(rows, '|')
+	if len(lines) != 3 {
+		t.Fatalf("len(lines) = %d, want 3 (header + 2 rows)", len(lines))
+	}
+
+	if lines[0] == "" {
+		t.Error("expected a non-empty header row")
+	}
+
+	// A service with no known state yet renders the spinner frame instead
+	// of a blank column.
+	if got := lines[2]; !strings.Contains(got, "|") {
+		t.Errorf("expected the pending service's row to show the spinner frame, got %q", got)
+	}
+}
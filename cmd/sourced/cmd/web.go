@@ -1,12 +1,9 @@
 package cmd
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"net/http"
 	"os"
-	"regexp"
 	"runtime"
 	"strings"
 	"time"
@@ -16,6 +13,8 @@ import (
 	"github.com/src-d/sourced-ce/cmd/sourced/compose"
 	"github.com/src-d/sourced-ce/cmd/sourced/compose/workdir"
 	"github.com/src-d/sourced-ce/cmd/sourced/dir"
+	"github.com/src-d/sourced-ce/cmd/sourced/srcderr"
+	"github.com/src-d/sourced-ce/cmd/sourced/wait"
 )
 
 // The service name used in docker-compose.yml for the srcd/sourced-ui image
@@ -26,27 +25,60 @@ type webCmd struct {
 }
 
 func (c *webCmd) Execute(args []string) error {
-	return OpenUI(2 * time.Second)
+	err := OpenUI(2 * time.Second)
+	if err != nil {
+		printRemediation(err)
+		os.Exit(srcderr.ExitCode(err))
+	}
+
+	return err
+}
+
+// printRemediation prints an actionable next step for well-known failure
+// categories, so users don't have to decode a raw error message to figure
+// out what to do about a failed `sourced web`.
+func printRemediation(err error) {
+	switch {
+	case srcderr.IsWorkdirInvalid(err):
+		fmt.Fprintln(os.Stderr, "run `sourced init local` first")
+	case srcderr.IsDockerUnavailable(err):
+		fmt.Fprintln(os.Stderr, "make sure Docker is running and reachable")
+	case srcderr.IsServiceExited(err):
+		if se, ok := srcderr.AsServiceExited(err); ok {
+			fmt.Fprintf(os.Stderr, "check `docker logs srcd-*-%s`\n", se.Service())
+		}
+	case srcderr.IsServiceUnhealthy(err):
+		if su, ok := srcderr.AsServiceUnhealthy(err); ok {
+			fmt.Fprintf(os.Stderr, "check `docker logs srcd-*-%s`\n", su.Service())
+		}
+	case srcderr.IsTimeout(err):
+		fmt.Fprintln(os.Stderr, "check `docker ps` to see which container is still initializing")
+	}
 }
 
 func init() {
 	rootCmd.AddCommand(&webCmd{})
 }
 
+// projectName returns the docker-compose project name sourced's containers
+// are labeled with, so the compose.Engine knows which containers belong to
+// this installation.
+func projectName() string {
+	if p := os.Getenv("COMPOSE_PROJECT_NAME"); p != "" {
+		return p
+	}
+
+	return "srcd"
+}
+
+// openUI opens the browser at the UI's address. The caller is expected to
+// have already waited for that address to become reachable, e.g. via
+// wait.AwaitReachable.
 func openUI(address string) error {
 	// docker-compose returns 0.0.0.0 which is correct for the bind address
 	// but incorrect as connect address
 	url := fmt.Sprintf("http://%s", strings.Replace(address, "0.0.0.0", "127.0.0.1", 1))
 
-	for {
-		client := http.Client{Timeout: time.Second}
-		if _, err := client.Get(url); err == nil {
-			break
-		}
-
-		time.Sleep(1 * time.Second)
-	}
-
 	if err := browser.OpenURL(url); err != nil {
 		return errors.Wrap(err, "could not open the browser")
 	}
@@ -54,128 +86,181 @@ func openUI(address string) error {
 	return nil
 }
 
-func checkFailFast(stdout *bytes.Buffer) (bool, error) {
-	err := compose.RunWithIO(context.Background(),
-		os.Stdin, stdout, nil, "port", containerName, "8088")
-	if workdir.ErrMalformed.Is(err) || dir.ErrNotExist.Is(err) || dir.ErrNotValid.Is(err) {
-		return true, err
+// serviceUp reports whether state represents a service that's done
+// starting, one way or another: either it's running and not failing its
+// healthcheck, or it's a workdir.LifecycleOneshot service that exited
+// cleanly.
+func serviceUp(policy workdir.ServicePolicy, service string, state compose.ServiceState) bool {
+	if state.Health == "unhealthy" {
+		return false
 	}
 
-	if err != nil {
-		return false, err
+	switch state.Status {
+	case "running":
+		return true
+	case "exited":
+		return policy.Lifecycle(service) == workdir.LifecycleOneshot && state.ExitCode == 0
+	default:
+		return false
 	}
-
-	return false, nil
 }
 
-func waitForContainer(stdout *bytes.Buffer) {
-	for {
-		if err := compose.RunWithIO(context.Background(),
-			os.Stdin, stdout, nil, "port", containerName, "8088"); err == nil {
-			break
+// serviceCallTimeout bounds each individual Docker SDK call
+// waitForServicesUp makes. Without it, a Docker daemon that hangs rather
+// than actively refusing the connection (so client.IsErrConnectionFailed
+// never trips) could block this loop, and therefore OpenUI, forever.
+const serviceCallTimeout = 5 * time.Second
+
+// waitForServicesUp blocks until every service in the compose project has
+// been scheduled and started by Docker, surfacing configuration errors (a
+// malformed workdir, a missing project) immediately instead of letting the
+// caller's timeout expire while sourced never got a chance to start. It
+// also returns the project's ServicePolicy, built from the services'
+// container labels, for runMonitor to use. It gives up once timeout
+// elapses, so a hung Docker daemon or a project that never reaches an up
+// state can't block OpenUI indefinitely.
+func waitForServicesUp(engine compose.Engine, timeout time.Duration) (failFast bool, policy workdir.ServicePolicy, err error) {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		ctx, cancel := context.WithTimeout(context.Background(), serviceCallTimeout)
+		services, err := engine.ListServices(ctx)
+		cancel()
+
+		if workdir.ErrMalformed.Is(err) || workdir.ErrNotExist.Is(err) || dir.ErrNotExist.Is(err) || dir.ErrNotValid.Is(err) {
+			return true, nil, srcderr.NewWorkdirInvalid(err)
 		}
 
-		time.Sleep(1 * time.Second)
-	}
-}
+		if srcderr.IsDockerUnavailable(err) {
+			return true, nil, err
+		}
 
-var stateExtractor = regexp.MustCompile(`(?m)^srcd-[\w\d]+.*(Up|Exit (\w+))`)
-
-// runMonitor checks the status of the containers in order to early exit in case
-// an unrecoverable error occurs.
-// The monitoring is performed by running `docker-compose ps <service>` for each
-// service returned by `docker-compose config --services`, and by grepping the
-// state from the stdout using a regex.
-// Getting the state of all the containers in a single pass by running `docker-compose ps`
-// and by using a multi-line regex to extract both service name and state is not reliable.
-// The reason is that the prefix of a container can be very long, especially for local
-// initialization, due to the value that we set for `COMPOSE_PROJECT_NAME` env var, and
-// docker-compose may split the name into multiple lines.
-// E.g.:
-//
-// Name                                                       Command                       State                                     Ports
-// ------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------
-// srcd-l1vzzxjzl3nln2vudhlzztdlbi9qcm9qzwn0cy8uz28td29ya3nwywnll3nyyy9naxrodwiuy29tl3nln   /bin/bblfsh-web -addr :808 ...   Up                      0.0.0.0:9999->8080/tcp
-// 2vudhlzztdlbg_bblfsh-web_1
-func runMonitor(ch chan<- error) {
-	runMonitorService := func(service string, ch chan<- error) {
-		for {
-			var stdout bytes.Buffer
-			if err := compose.RunWithIO(context.Background(),
-				os.Stdin, &stdout, nil, "ps", service); err != nil {
-				ch <- errors.Wrapf(err, "cannot get status service %s", service)
-				return
-			}
+		if err == nil {
+			if len(services) == 0 {
+				// A native Engine never touches the project's files, so an
+				// empty service list here doesn't mean "everything is up":
+				// it's just as likely to mean `sourced init` was never
+				// run. Validate the workdir explicitly instead of trusting
+				// the vacuous case.
+				if verr := workdir.Validate("."); verr != nil {
+					return true, nil, srcderr.NewWorkdirInvalid(verr)
+				}
+			} else {
+				states := make(map[string]compose.ServiceState, len(services))
+				labels := make(map[string]map[string]string, len(services))
+				statesOK := true
+				for _, service := range services {
+					ctx, cancel := context.WithTimeout(context.Background(), serviceCallTimeout)
+					state, err := engine.ServiceState(ctx, service)
+					cancel()
+					if err != nil {
+						statesOK = false
+						break
+					}
 
-			matches := stateExtractor.FindAllStringSubmatch(
-				strings.TrimSpace(stdout.String()), -1)
-			for _, match := range matches {
-				state := match[1]
+					states[service] = state
+					labels[service] = state.Labels
+				}
 
-				if strings.HasPrefix(state, "Exit") {
-					if service != "ghsync" && service != "gitcollector" {
-						ch <- fmt.Errorf("service '%s' is in state '%s'", service, state)
-						return
+				if statesOK {
+					policy, err := workdir.LoadServicePolicy(".", labels)
+					if err != nil {
+						return true, nil, srcderr.NewWorkdirInvalid(err)
 					}
 
-					returnCode := state[len("Exit "):len(state)]
-					if returnCode != "0" {
-						ch <- fmt.Errorf("service '%s' exited with return code: %s",
-							service, returnCode)
-						return
+					allUp := true
+					for service, state := range states {
+						if !serviceUp(policy, service, state) {
+							allUp = false
+							break
+						}
 					}
 
-					continue
+					if allUp {
+						return false, policy, nil
+					}
 				}
+			}
+		}
 
-				if state != "Up" {
-					ch <- fmt.Errorf("service '%s' is in state '%s'", service, state)
-					return
-				}
+		time.Sleep(1 * time.Second)
+	}
 
-			}
+	return true, nil, srcderr.NewTimeout(
+		fmt.Sprintf("services were not all up after %v", timeout))
+}
 
-			time.Sleep(1 * time.Second)
-		}
+// runMonitor checks the status of the containers in order to early exit in
+// case an unrecoverable error occurs. It subscribes to a single
+// compose.Monitor for the whole project instead of running one
+// docker-compose ps polling loop per service, and treats services
+// according to policy instead of a hard-coded allowlist.
+func runMonitor(ctx context.Context, engine compose.Engine, policy workdir.ServicePolicy, ch chan<- error) {
+	events, err := compose.NewMonitor(engine).Subscribe(ctx)
+	if err != nil {
+		ch <- errors.Wrap(err, "cannot subscribe to service events")
+		return
 	}
 
 	go func() {
-		var servicesBuf bytes.Buffer
-		if err := compose.RunWithIO(context.Background(),
-			os.Stdin, &servicesBuf, nil, "config", "--services"); err != nil {
-			ch <- errors.Wrap(err, "cannot get list of services")
-			return
-		}
+		for ev := range events {
+			if ev.Kind == compose.ServiceUnhealthyEvent {
+				ch <- srcderr.NewServiceUnhealthy(ev.Service, "failed its healthcheck")
+				return
+			}
 
-		services := strings.Split(strings.TrimSpace(servicesBuf.String()), "\n")
+			if serviceUp(policy, ev.Service, ev.State) {
+				continue
+			}
 
-		for _, service := range services {
-			go runMonitorService(service, ch)
+			if ev.State.Status == "exited" {
+				ch <- srcderr.NewServiceExited(ev.Service, ev.State.ExitCode)
+			} else {
+				ch <- srcderr.NewServiceUnhealthy(ev.Service,
+					fmt.Sprintf("is in state '%s'", ev.State.Status))
+			}
+
+			return
 		}
 	}()
 }
 
 // OpenUI opens the browser with the UI.
 func OpenUI(timeout time.Duration) error {
-	var stdout bytes.Buffer
-	failFast, err := checkFailFast(&stdout)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := compose.NewEngine(projectName())
+	if err != nil {
+		return err
+	}
+
+	failFast, policy, err := waitForServicesUp(engine, timeout)
 	if failFast {
 		return err
 	}
 
+	// The timeout only starts counting down once every service has been
+	// started by Docker, so a slow `docker pull` doesn't eat into the
+	// budget sourced-ui gets to become healthy.
 	ch := make(chan error)
-	containerReady := err == nil
 
-	runMonitor(ch)
+	runMonitor(ctx, engine, policy, ch)
 
 	go func() {
-		if !containerReady {
-			waitForContainer(&stdout)
+		if err := wait.AwaitHealthy(engine, containerName, timeout); err != nil {
+			ch <- err
+			return
 		}
 
-		address := strings.TrimSpace(stdout.String())
-		if address == "" {
-			ch <- fmt.Errorf("could not find the public port of %s", containerName)
+		address, err := engine.PortForService(context.Background(), containerName, "8088")
+		if err != nil {
+			ch <- errors.Wrapf(err, "could not find the public port of %s", containerName)
+			return
+		}
+
+		if err := wait.AwaitReachable(address, timeout); err != nil {
+			ch <- err
 			return
 		}
 
@@ -198,7 +283,8 @@ Once source{d} is fully initialized, the UI will be available, by default at:
 	case err := <-ch:
 		return err
 	case <-time.After(timeout):
-		return fmt.Errorf("error opening the UI, the container is not running after %v", timeout)
+		return srcderr.NewTimeout(
+			fmt.Sprintf("error opening the UI, the container is not running after %v", timeout))
 	}
 }
 
@@ -210,15 +296,21 @@ type spinner struct {
 	stop chan bool
 }
 
-func startSpinner(msg string) func() {
-	charset := []int{'⠋', '⠙', '⠹', '⠸', '⠼', '⠴', '⠦', '⠧', '⠇', '⠏'}
+// spinnerCharset returns the character sequence used to animate a spinner
+// on the current platform, cycling ASCII on Windows terminals that can't
+// render the Braille dot patterns.
+func spinnerCharset() []int {
 	if runtime.GOOS == "windows" {
-		charset = []int{'|', '/', '-', '\\'}
+		return []int{'|', '/', '-', '\\'}
 	}
 
+	return []int{'⠋', '⠙', '⠹', '⠸', '⠼', '⠴', '⠦', '⠧', '⠇', '⠏'}
+}
+
+func startSpinner(msg string) func() {
 	s := &spinner{
 		msg:      msg,
-		charset:  charset,
+		charset:  spinnerCharset(),
 		interval: 200 * time.Millisecond,
 		stop:     make(chan bool),
 	}
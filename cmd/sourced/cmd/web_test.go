@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/src-d/sourced-ce/cmd/sourced/compose"
+	"github.com/src-d/sourced-ce/cmd/sourced/compose/workdir"
+)
+
+func TestServiceUp(t *testing.T) {
+	policy := workdir.ServicePolicy{"ghsync": workdir.LifecycleOneshot}
+
+	cases := []struct {
+		name    string
+		service string
+		state   compose.ServiceState
+		want    bool
+	}{
+		{"running", "sourced-ui", compose.ServiceState{Status: "running"}, true},
+		{"running but unhealthy", "sourced-ui", compose.ServiceState{Status: "running", Health: "unhealthy"}, false},
+		{"running and healthy", "sourced-ui", compose.ServiceState{Status: "running", Health: "healthy"}, true},
+		{"oneshot clean exit", "ghsync", compose.ServiceState{Status: "exited", ExitCode: 0}, true},
+		{"oneshot failed exit", "ghsync", compose.ServiceState{Status: "exited", ExitCode: 1}, false},
+		{"longrunning exited", "sourced-ui", compose.ServiceState{Status: "exited", ExitCode: 0}, false},
+		{"restarting", "sourced-ui", compose.ServiceState{Status: "restarting"}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := serviceUp(policy, c.service, c.state); got != c.want {
+				t.Errorf("serviceUp(%s) = %v, want %v", c.name, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+// Package compose provides access to the docker-compose project that backs
+// a sourced installation, either through the Docker Engine SDK directly or,
+// as a fallback, by shelling out to the docker-compose binary.
+package compose
+
+import (
+	"context"
+	"io"
+	"os/exec"
+)
+
+// RunWithIO runs docker-compose with the given args, wiring stdin, stdout
+// and stderr to the given streams. It is the low-level primitive the legacy
+// Engine implementation is built on top of.
+func RunWithIO(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "docker-compose", args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	return cmd.Run()
+}
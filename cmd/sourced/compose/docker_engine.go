@@ -0,0 +1,179 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	dockerevents "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"github.com/src-d/sourced-ce/cmd/sourced/srcderr"
+)
+
+const (
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+)
+
+// dockerEngine implements Engine using the Docker Engine SDK directly,
+// identifying a project's containers by their compose labels instead of
+// shelling out to docker-compose and scraping its output. Up and Down still
+// run the docker-compose binary through RunWithIO.
+type dockerEngine struct {
+	project string
+	cli     *client.Client
+}
+
+func newDockerEngine(project string) (*dockerEngine, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, srcderr.NewDockerUnavailable(errors.Wrap(err, "cannot connect to the Docker daemon"))
+	}
+
+	return &dockerEngine{project: project, cli: cli}, nil
+}
+
+// wrapDockerErr promotes a connection-level Docker API error (daemon
+// unreachable, socket gone) to a srcderr.DockerUnavailable, leaving other
+// errors (e.g. a container simply not existing) untouched.
+func wrapDockerErr(err error) error {
+	if err != nil && client.IsErrConnectionFailed(err) {
+		return srcderr.NewDockerUnavailable(err)
+	}
+
+	return err
+}
+
+func (e *dockerEngine) containerFilters(service string) filters.Args {
+	args := filters.NewArgs()
+	args.Add("label", composeProjectLabel+"="+e.project)
+	if service != "" {
+		args.Add("label", composeServiceLabel+"="+service)
+	}
+
+	return args
+}
+
+func (e *dockerEngine) container(ctx context.Context, service string) (types.Container, error) {
+	containers, err := e.cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: e.containerFilters(service),
+	})
+	if err != nil {
+		return types.Container{}, errors.Wrapf(wrapDockerErr(err), "cannot find container for service %s", service)
+	}
+
+	if len(containers) == 0 {
+		return types.Container{}, errors.Errorf("no container found for service %s", service)
+	}
+
+	return containers[0], nil
+}
+
+func (e *dockerEngine) Up(ctx context.Context) error {
+	return RunWithIO(ctx, nil, nil, nil, "up", "-d")
+}
+
+func (e *dockerEngine) Down(ctx context.Context) error {
+	return RunWithIO(ctx, nil, nil, nil, "down")
+}
+
+func (e *dockerEngine) ListServices(ctx context.Context) ([]string, error) {
+	containers, err := e.cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: e.containerFilters(""),
+	})
+	if err != nil {
+		return nil, errors.Wrap(wrapDockerErr(err), "cannot list containers")
+	}
+
+	seen := make(map[string]bool, len(containers))
+	services := make([]string, 0, len(containers))
+	for _, c := range containers {
+		service := c.Labels[composeServiceLabel]
+		if service == "" || seen[service] {
+			continue
+		}
+
+		seen[service] = true
+		services = append(services, service)
+	}
+
+	return services, nil
+}
+
+func (e *dockerEngine) ServiceState(ctx context.Context, service string) (ServiceState, error) {
+	c, err := e.container(ctx, service)
+	if err != nil {
+		return ServiceState{}, err
+	}
+
+	info, err := e.cli.ContainerInspect(ctx, c.ID)
+	if err != nil {
+		return ServiceState{}, errors.Wrapf(wrapDockerErr(err), "cannot inspect container for service %s", service)
+	}
+
+	state := ServiceState{
+		Service:  service,
+		Status:   info.State.Status,
+		ExitCode: info.State.ExitCode,
+		Labels:   c.Labels,
+	}
+	if info.State.Health != nil {
+		state.Health = info.State.Health.Status
+	}
+
+	if startedAt, err := time.Parse(time.RFC3339Nano, info.State.StartedAt); err == nil {
+		state.StartedAt = startedAt
+	}
+
+	for _, p := range c.Ports {
+		if p.PublicPort == 0 {
+			continue
+		}
+
+		host := p.IP
+		if host == "" || host == "0.0.0.0" {
+			host = "127.0.0.1"
+		}
+
+		state.Ports = append(state.Ports,
+			fmt.Sprintf("%s:%d->%d/%s", host, p.PublicPort, p.PrivatePort, p.Type))
+	}
+
+	return state, nil
+}
+
+// events implements the eventSource interface Monitor uses to subscribe to
+// this project's containers directly, instead of polling their state.
+func (e *dockerEngine) events(ctx context.Context) (<-chan dockerevents.Message, <-chan error) {
+	return e.cli.Events(ctx, types.EventsOptions{
+		Filters: e.containerFilters(""),
+	})
+}
+
+func (e *dockerEngine) PortForService(ctx context.Context, service, port string) (string, error) {
+	c, err := e.container(ctx, service)
+	if err != nil {
+		return "", err
+	}
+
+	for _, p := range c.Ports {
+		if strconv.Itoa(int(p.PrivatePort)) != port || p.PublicPort == 0 {
+			continue
+		}
+
+		host := p.IP
+		if host == "" || host == "0.0.0.0" {
+			host = "127.0.0.1"
+		}
+
+		return fmt.Sprintf("%s:%d", host, p.PublicPort), nil
+	}
+
+	return "", errors.Errorf("port %s is not allocated for service %s", port, service)
+}
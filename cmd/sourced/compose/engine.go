@@ -0,0 +1,69 @@
+package compose
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// engineEnvVar selects which Engine implementation NewEngine returns. When
+// unset, the native Docker Engine SDK backend is used. Environments without
+// access to the Docker socket (e.g. a remote Docker host reachable only
+// through the docker-compose CLI's own config) can fall back to shelling
+// out to docker-compose by setting this to "legacy".
+const engineEnvVar = "SOURCED_COMPOSE_ENGINE"
+
+// ServiceState describes the observed state of a single compose service's
+// container.
+type ServiceState struct {
+	Service string
+	// Status mirrors Docker's container State.Status, e.g. "running",
+	// "exited", "restarting", "created".
+	Status   string
+	ExitCode int
+	// Health mirrors Docker's container State.Health.Status, e.g.
+	// "healthy", "unhealthy", "starting". It is empty when the service
+	// declares no healthcheck.
+	Health string
+	// Labels carries the container's Docker labels, e.g. the
+	// com.sourced.lifecycle lifecycle policy label. Not all Engine
+	// implementations can populate this.
+	Labels map[string]string
+	// StartedAt is when the container last started. Zero when unknown.
+	StartedAt time.Time
+	// Ports lists the container's published ports, formatted as
+	// "host:hostPort->containerPort/proto". Not all Engine implementations
+	// can populate this.
+	Ports []string
+}
+
+// Engine abstracts the operations sourced needs to perform against a
+// docker-compose project, so callers don't need to know whether they're
+// talking to the Docker Engine SDK or to the docker-compose binary.
+type Engine interface {
+	// Up brings up the project's services, equivalent to `docker-compose up -d`.
+	Up(ctx context.Context) error
+	// Down tears down the project's services, equivalent to `docker-compose down`.
+	Down(ctx context.Context) error
+	// ListServices returns the names of the project's services. The legacy
+	// implementation returns the services declared in the project's compose
+	// configuration; the Docker Engine SDK implementation returns only the
+	// services that currently have a container labeled with the project.
+	ListServices(ctx context.Context) ([]string, error)
+	// ServiceState returns the current state of the given service's container.
+	ServiceState(ctx context.Context, service string) (ServiceState, error)
+	// PortForService returns the host:port a given container port is
+	// published on for the given service.
+	PortForService(ctx context.Context, service, port string) (string, error)
+}
+
+// NewEngine returns the Engine implementation selected by the
+// SOURCED_COMPOSE_ENGINE environment variable for the given docker-compose
+// project name, defaulting to the native Docker Engine SDK backend.
+func NewEngine(project string) (Engine, error) {
+	if os.Getenv(engineEnvVar) == "legacy" {
+		return newLegacyEngine(project), nil
+	}
+
+	return newDockerEngine(project)
+}
@@ -0,0 +1,81 @@
+package compose
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// legacyEngine implements Engine by shelling out to the docker-compose
+// binary and scraping its textual output. It only exists as a fallback for
+// environments where this process can't reach the Docker socket directly;
+// newDockerEngine should be preferred whenever possible.
+type legacyEngine struct {
+	project string
+}
+
+func newLegacyEngine(project string) *legacyEngine {
+	return &legacyEngine{project: project}
+}
+
+func (e *legacyEngine) Up(ctx context.Context) error {
+	return RunWithIO(ctx, nil, nil, nil, "up", "-d")
+}
+
+func (e *legacyEngine) Down(ctx context.Context) error {
+	return RunWithIO(ctx, nil, nil, nil, "down")
+}
+
+func (e *legacyEngine) ListServices(ctx context.Context) ([]string, error) {
+	var stdout bytes.Buffer
+	if err := RunWithIO(ctx, nil, &stdout, nil, "config", "--services"); err != nil {
+		return nil, errors.Wrap(err, "cannot get list of services")
+	}
+
+	services := strings.TrimSpace(stdout.String())
+	if services == "" {
+		return nil, nil
+	}
+
+	return strings.Split(services, "\n"), nil
+}
+
+// stateExtractor scrapes the service state out of `docker-compose ps`
+// output. This is best-effort and not fully reliable: the prefix of a
+// container name can be very long, especially for local initialization, due
+// to the value that we set for COMPOSE_PROJECT_NAME, and docker-compose may
+// split the name into multiple lines.
+var stateExtractor = regexp.MustCompile(`(?m)^srcd-[\w\d]+.*(Up|Exit (\w+))`)
+
+func (e *legacyEngine) ServiceState(ctx context.Context, service string) (ServiceState, error) {
+	var stdout bytes.Buffer
+	if err := RunWithIO(ctx, nil, &stdout, nil, "ps", service); err != nil {
+		return ServiceState{}, errors.Wrapf(err, "cannot get status of service %s", service)
+	}
+
+	matches := stateExtractor.FindStringSubmatch(strings.TrimSpace(stdout.String()))
+	if matches == nil {
+		return ServiceState{Service: service, Status: "unknown"}, nil
+	}
+
+	state := matches[1]
+	if strings.HasPrefix(state, "Exit") {
+		code, _ := strconv.Atoi(strings.TrimPrefix(state, "Exit "))
+		return ServiceState{Service: service, Status: "exited", ExitCode: code}, nil
+	}
+
+	return ServiceState{Service: service, Status: "running"}, nil
+}
+
+func (e *legacyEngine) PortForService(ctx context.Context, service, port string) (string, error) {
+	var stdout bytes.Buffer
+	if err := RunWithIO(ctx, nil, &stdout, nil, "port", service, port); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
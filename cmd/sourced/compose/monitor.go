@@ -0,0 +1,244 @@
+package compose
+
+import (
+	"context"
+	"time"
+
+	dockerevents "github.com/docker/docker/api/types/events"
+	"github.com/pkg/errors"
+)
+
+// ServiceEventKind enumerates the container lifecycle transitions a Monitor
+// reports.
+type ServiceEventKind string
+
+const (
+	// ServiceSnapshot reports a service's current state, independent of any
+	// particular transition: it's emitted for the initial state of every
+	// service, and again for every service whenever the event stream has to
+	// be re-established. A polling Monitor also emits it on every poll for a
+	// service whose status did not change.
+	ServiceSnapshot ServiceEventKind = "snapshot"
+	// ServiceStarted reports that a service's container started.
+	ServiceStarted ServiceEventKind = "start"
+	// ServiceExitedEvent reports that a service's container exited.
+	ServiceExitedEvent ServiceEventKind = "die"
+	// ServiceHealthy reports that a service's healthcheck started passing.
+	ServiceHealthy ServiceEventKind = "healthy"
+	// ServiceUnhealthyEvent reports that a service's healthcheck started failing.
+	ServiceUnhealthyEvent ServiceEventKind = "unhealthy"
+)
+
+// ServiceEvent reports a state transition observed for a service's
+// container, along with its state at the time of the event.
+type ServiceEvent struct {
+	Service string
+	Kind    ServiceEventKind
+	State   ServiceState
+	Time    time.Time
+}
+
+// Monitor streams ServiceEvents for a docker-compose project, so callers
+// don't have to poll each service's state themselves.
+type Monitor interface {
+	// Subscribe starts monitoring and returns a channel of ServiceEvents.
+	// The channel is closed once ctx is done.
+	Subscribe(ctx context.Context) (<-chan ServiceEvent, error)
+}
+
+// eventSource is implemented by Engines that can stream container events
+// directly, rather than requiring Monitor to poll ServiceState.
+type eventSource interface {
+	events(ctx context.Context) (<-chan dockerevents.Message, <-chan error)
+}
+
+// NewMonitor returns a Monitor for engine: an event-driven one backed by
+// the Docker events stream when engine supports it, falling back to
+// polling ServiceState on a fixed interval otherwise.
+func NewMonitor(engine Engine) Monitor {
+	if es, ok := engine.(eventSource); ok {
+		return &eventMonitor{engine: engine, source: es}
+	}
+
+	return &pollingMonitor{engine: engine, interval: time.Second}
+}
+
+// transitionKind classifies state into the ServiceEventKind a polling pass
+// reports for a service whose status changed to state.Status.
+func transitionKind(state ServiceState) ServiceEventKind {
+	switch state.Status {
+	case "running":
+		return ServiceStarted
+	case "exited":
+		return ServiceExitedEvent
+	default:
+		return ServiceSnapshot
+	}
+}
+
+// snapshot emits a ServiceSnapshot for every service whose state it can
+// read, and returns the project's services along with the status each
+// reported one was in.
+func snapshot(ctx context.Context, engine Engine, out chan<- ServiceEvent) ([]string, map[string]string) {
+	services, err := engine.ListServices(ctx)
+	if err != nil {
+		return nil, map[string]string{}
+	}
+
+	statuses := make(map[string]string, len(services))
+	for _, service := range services {
+		if state, err := engine.ServiceState(ctx, service); err == nil {
+			statuses[service] = state.Status
+			out <- ServiceEvent{Service: service, Kind: ServiceSnapshot, State: state, Time: time.Now()}
+		}
+	}
+
+	return services, statuses
+}
+
+// eventMonitor implements Monitor on top of the Docker engine's /events
+// stream: a single long-lived subscription replaces the one-goroutine-
+// per-service `docker-compose ps` polling loop the legacy monitor needs.
+// It only falls back to a ServiceState snapshot for the initial state of
+// each service, or when the events connection drops and has to be
+// re-established.
+type eventMonitor struct {
+	engine Engine
+	source eventSource
+}
+
+func (m *eventMonitor) Subscribe(ctx context.Context) (<-chan ServiceEvent, error) {
+	out := make(chan ServiceEvent)
+
+	go func() {
+		defer close(out)
+
+		snapshot(ctx, m.engine, out)
+
+		for {
+			// stream returns once ctx is done or the connection dropped;
+			// only the former ends the subscription.
+			if err := m.stream(ctx, out); err != nil && ctx.Err() != nil {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+
+			snapshot(ctx, m.engine, out)
+		}
+	}()
+
+	return out, nil
+}
+
+// stream consumes the Docker events stream until it's closed by ctx being
+// done, or errors out because the connection to the daemon dropped.
+func (m *eventMonitor) stream(ctx context.Context, out chan<- ServiceEvent) error {
+	msgs, errs := m.source.events(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-msgs:
+			if !ok {
+				return errors.New("docker events stream closed")
+			}
+
+			if ev, ok := toServiceEvent(ctx, m.engine, msg); ok {
+				out <- ev
+			}
+		case err, ok := <-errs:
+			if !ok {
+				return errors.New("docker events stream closed")
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toServiceEvent(ctx context.Context, engine Engine, msg dockerevents.Message) (ServiceEvent, bool) {
+	service := msg.Actor.Attributes[composeServiceLabel]
+	if service == "" {
+		return ServiceEvent{}, false
+	}
+
+	var kind ServiceEventKind
+	switch msg.Action {
+	case "start":
+		kind = ServiceStarted
+	case "die":
+		kind = ServiceExitedEvent
+	case "health_status: healthy":
+		kind = ServiceHealthy
+	case "health_status: unhealthy":
+		kind = ServiceUnhealthyEvent
+	default:
+		return ServiceEvent{}, false
+	}
+
+	state, err := engine.ServiceState(ctx, service)
+	if err != nil {
+		return ServiceEvent{}, false
+	}
+
+	return ServiceEvent{
+		Service: service,
+		Kind:    kind,
+		State:   state,
+		Time:    time.Unix(0, msg.TimeNano),
+	}, true
+}
+
+// pollingMonitor implements Monitor by repeatedly polling ServiceState, for
+// Engine implementations (like the legacy one) that can't stream events.
+type pollingMonitor struct {
+	engine   Engine
+	interval time.Duration
+}
+
+func (m *pollingMonitor) Subscribe(ctx context.Context) (<-chan ServiceEvent, error) {
+	out := make(chan ServiceEvent)
+
+	go func() {
+		defer close(out)
+
+		services, last := snapshot(ctx, m.engine, out)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(m.interval):
+			}
+
+			for _, service := range services {
+				state, err := m.engine.ServiceState(ctx, service)
+				if err != nil {
+					continue
+				}
+
+				kind := ServiceSnapshot
+				if state.Status != last[service] {
+					kind = transitionKind(state)
+					last[service] = state.Status
+				}
+
+				select {
+				case out <- ServiceEvent{Service: service, Kind: kind, State: state, Time: time.Now()}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,23 @@
+package compose
+
+import "testing"
+
+func TestTransitionKind(t *testing.T) {
+	cases := []struct {
+		status string
+		want   ServiceEventKind
+	}{
+		{"running", ServiceStarted},
+		{"exited", ServiceExitedEvent},
+		{"restarting", ServiceSnapshot},
+		{"created", ServiceSnapshot},
+		{"", ServiceSnapshot},
+	}
+
+	for _, c := range cases {
+		state := ServiceState{Status: c.status}
+		if got := transitionKind(state); got != c.want {
+			t.Errorf("transitionKind(%q) = %q, want %q", c.status, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package workdir
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Lifecycle describes how sourced's container monitor should treat a
+// service's container once docker-compose has started it.
+type Lifecycle string
+
+const (
+	// LifecycleLongRunning is the default: the service is expected to keep
+	// running, so exiting at any point counts as a failure.
+	LifecycleLongRunning Lifecycle = "longrunning"
+	// LifecycleOneshot marks a service that's expected to run once and
+	// exit, e.g. a schema migrator or a repo-bootstrap job. A clean exit
+	// (code 0) counts as success; any other exit is still a failure.
+	LifecycleOneshot Lifecycle = "oneshot"
+)
+
+// LifecycleLabel is the docker-compose label a service can carry to declare
+// its own Lifecycle, instead of sourced hard-coding a service name
+// allowlist.
+const LifecycleLabel = "com.sourced.lifecycle"
+
+// servicesFile is an optional sibling of docker-compose.yml where a
+// service's Lifecycle can be declared without editing its labels.
+const servicesFile = "services.yml"
+
+// ServicePolicy maps a service name to its declared Lifecycle.
+type ServicePolicy map[string]Lifecycle
+
+// Lifecycle returns service's declared Lifecycle, defaulting to
+// LifecycleLongRunning when it wasn't declared anywhere.
+func (p ServicePolicy) Lifecycle(service string) Lifecycle {
+	if l, ok := p[service]; ok && l != "" {
+		return l
+	}
+
+	return LifecycleLongRunning
+}
+
+type servicesYAML struct {
+	Services map[string]struct {
+		Lifecycle Lifecycle `yaml:"lifecycle"`
+	} `yaml:"services"`
+}
+
+// LoadServicePolicy builds a ServicePolicy for the docker-compose project
+// rooted at root, from the com.sourced.lifecycle label carried by each
+// service's container (passed in as labels, keyed by service name) and, if
+// present, the declarations in root/services.yml. Labels take precedence,
+// since they travel with docker-compose.yml itself.
+func LoadServicePolicy(root string, labels map[string]map[string]string) (ServicePolicy, error) {
+	policy := ServicePolicy{}
+
+	raw, err := ioutil.ReadFile(filepath.Join(root, servicesFile))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, ErrMalformed.New(fmt.Sprintf("cannot read %s: %s", servicesFile, err))
+	default:
+		var parsed servicesYAML
+		if err := yaml.Unmarshal(raw, &parsed); err != nil {
+			return nil, ErrMalformed.New(fmt.Sprintf("cannot parse %s: %s", servicesFile, err))
+		}
+
+		for service, cfg := range parsed.Services {
+			if cfg.Lifecycle != "" {
+				policy[service] = cfg.Lifecycle
+			}
+		}
+	}
+
+	for service, serviceLabels := range labels {
+		if l, ok := serviceLabels[LifecycleLabel]; ok && l != "" {
+			policy[service] = Lifecycle(l)
+		}
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,118 @@
+package workdir
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServicePolicyLifecycle(t *testing.T) {
+	policy := ServicePolicy{"ghsync": LifecycleOneshot}
+
+	cases := []struct {
+		service string
+		want    Lifecycle
+	}{
+		{"ghsync", LifecycleOneshot},
+		{"sourced-ui", LifecycleLongRunning},
+	}
+
+	for _, c := range cases {
+		if got := policy.Lifecycle(c.service); got != c.want {
+			t.Errorf("Lifecycle(%q) = %q, want %q", c.service, got, c.want)
+		}
+	}
+}
+
+func TestLoadServicePolicyFromLabels(t *testing.T) {
+	root, cleanup := tmpWorkdir(t, "")
+	defer cleanup()
+
+	labels := map[string]map[string]string{
+		"ghsync":     {LifecycleLabel: "oneshot"},
+		"sourced-ui": {},
+	}
+
+	policy, err := LoadServicePolicy(root, labels)
+	if err != nil {
+		t.Fatalf("LoadServicePolicy() error = %v", err)
+	}
+
+	if got := policy.Lifecycle("ghsync"); got != LifecycleOneshot {
+		t.Errorf("Lifecycle(ghsync) = %q, want %q", got, LifecycleOneshot)
+	}
+
+	if got := policy.Lifecycle("sourced-ui"); got != LifecycleLongRunning {
+		t.Errorf("Lifecycle(sourced-ui) = %q, want %q", got, LifecycleLongRunning)
+	}
+}
+
+func TestLoadServicePolicyFromServicesFile(t *testing.T) {
+	root, cleanup := tmpWorkdir(t, `
+services:
+  gitcollector:
+    lifecycle: oneshot
+`)
+	defer cleanup()
+
+	policy, err := LoadServicePolicy(root, nil)
+	if err != nil {
+		t.Fatalf("LoadServicePolicy() error = %v", err)
+	}
+
+	if got := policy.Lifecycle("gitcollector"); got != LifecycleOneshot {
+		t.Errorf("Lifecycle(gitcollector) = %q, want %q", got, LifecycleOneshot)
+	}
+}
+
+func TestLoadServicePolicyLabelsOverrideServicesFile(t *testing.T) {
+	root, cleanup := tmpWorkdir(t, `
+services:
+  gitcollector:
+    lifecycle: oneshot
+`)
+	defer cleanup()
+
+	labels := map[string]map[string]string{
+		"gitcollector": {LifecycleLabel: "longrunning"},
+	}
+
+	policy, err := LoadServicePolicy(root, labels)
+	if err != nil {
+		t.Fatalf("LoadServicePolicy() error = %v", err)
+	}
+
+	if got := policy.Lifecycle("gitcollector"); got != LifecycleLongRunning {
+		t.Errorf("Lifecycle(gitcollector) = %q, want %q", got, LifecycleLongRunning)
+	}
+}
+
+func TestLoadServicePolicyMalformedServicesFile(t *testing.T) {
+	root, cleanup := tmpWorkdir(t, "services: [this is not a services map")
+	defer cleanup()
+
+	if _, err := LoadServicePolicy(root, nil); !ErrMalformed.Is(err) {
+		t.Fatalf("LoadServicePolicy() error = %v, want ErrMalformed", err)
+	}
+}
+
+// tmpWorkdir creates a temporary directory and, if servicesYAML is
+// non-empty, writes it as that directory's services.yml.
+func tmpWorkdir(t *testing.T, servicesYAML string) (root string, cleanup func()) {
+	t.Helper()
+
+	root, err := ioutil.TempDir("", "workdir-test")
+	if err != nil {
+		t.Fatalf("TempDir() error = %v", err)
+	}
+
+	if servicesYAML != "" {
+		path := filepath.Join(root, servicesFile)
+		if err := ioutil.WriteFile(path, []byte(servicesYAML), 0644); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", path, err)
+		}
+	}
+
+	return root, func() { os.RemoveAll(root) }
+}
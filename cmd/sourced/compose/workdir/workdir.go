@@ -0,0 +1,41 @@
+// Package workdir locates and validates the docker-compose project that
+// backs a sourced installation.
+package workdir
+
+import (
+	"os"
+	"path/filepath"
+
+	errors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrMalformed is returned when the workdir's docker-compose.yml, or an
+// adjacent configuration file such as services.yml, can't be read or parsed.
+var ErrMalformed = errors.NewKind("workdir is malformed: %s")
+
+// ErrNotExist is returned when a workdir has no docker-compose.yml at all,
+// e.g. because `sourced init` was never run.
+var ErrNotExist = errors.NewKind("workdir not found: %s")
+
+// composeFile is the file whose presence marks root as an initialized
+// docker-compose project.
+const composeFile = "docker-compose.yml"
+
+// Validate confirms that root holds an initialized docker-compose project,
+// by checking for its docker-compose.yml. Callers that only learn a
+// project's state through Engine.ListServices need this: the native Docker
+// Engine SDK backend has no notion of the project's files, so an empty
+// service list it returns is indistinguishable from "sourced was never
+// initialized" without checking the workdir directly.
+func Validate(root string) error {
+	path := filepath.Join(root, composeFile)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotExist.New(path)
+		}
+
+		return ErrMalformed.New(err.Error())
+	}
+
+	return nil
+}
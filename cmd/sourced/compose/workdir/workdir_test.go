@@ -0,0 +1,37 @@
+package workdir
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateMissingComposeFile(t *testing.T) {
+	root, err := ioutil.TempDir("", "workdir-validate-test")
+	if err != nil {
+		t.Fatalf("TempDir() error = %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := Validate(root); !ErrNotExist.Is(err) {
+		t.Fatalf("Validate() error = %v, want ErrNotExist", err)
+	}
+}
+
+func TestValidateOK(t *testing.T) {
+	root, err := ioutil.TempDir("", "workdir-validate-test")
+	if err != nil {
+		t.Fatalf("TempDir() error = %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	path := filepath.Join(root, composeFile)
+	if err := ioutil.WriteFile(path, []byte("version: '3'\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if err := Validate(root); err != nil {
+		t.Fatalf("Validate() error = %v, want nil", err)
+	}
+}
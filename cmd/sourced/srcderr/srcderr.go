@@ -0,0 +1,244 @@
+// Package srcderr defines the typed error categories sourced's init/web
+// commands can fail with, following the same approach as moby's
+// api/errdefs: each category is a small marker interface a concrete error
+// can implement, plus an IsXxx helper that walks the error's cause chain
+// looking for it. This lets callers branch on what went wrong instead of
+// string-matching error messages.
+package srcderr
+
+// causer is implemented by github.com/pkg/errors' wrapped errors, letting
+// the IsXxx helpers see through error wrapping added along the call chain.
+type causer interface {
+	Cause() error
+}
+
+// chain returns err and every error it wraps, outermost first.
+func chain(err error) []error {
+	var errs []error
+	for err != nil {
+		errs = append(errs, err)
+
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+
+		err = c.Cause()
+	}
+
+	return errs
+}
+
+// ServiceExited is implemented by errors reporting that a service's
+// container exited, either unexpectedly or with a non-zero code.
+type ServiceExited interface {
+	error
+	Service() string
+	ExitCode() int
+	ServiceExited()
+}
+
+type serviceExited struct {
+	service  string
+	exitCode int
+}
+
+// NewServiceExited returns an error reporting that service exited with
+// exitCode when it was expected to either keep running or exit cleanly.
+func NewServiceExited(service string, exitCode int) error {
+	return &serviceExited{service: service, exitCode: exitCode}
+}
+
+func (e *serviceExited) Error() string {
+	return "service '" + e.service + "' exited unexpectedly"
+}
+func (e *serviceExited) Service() string { return e.service }
+func (e *serviceExited) ExitCode() int   { return e.exitCode }
+func (e *serviceExited) ServiceExited()  {}
+
+// AsServiceExited returns the ServiceExited in err's cause chain, if any.
+func AsServiceExited(err error) (ServiceExited, bool) {
+	for _, e := range chain(err) {
+		if se, ok := e.(ServiceExited); ok {
+			return se, true
+		}
+	}
+
+	return nil, false
+}
+
+// IsServiceExited reports whether err (or a cause of it) is a ServiceExited.
+func IsServiceExited(err error) bool {
+	_, ok := AsServiceExited(err)
+	return ok
+}
+
+// ServiceUnhealthy is implemented by errors reporting that a service's
+// container is running but isn't in a usable state, e.g. it failed its
+// Docker healthcheck or is stuck restarting.
+type ServiceUnhealthy interface {
+	error
+	Service() string
+	ServiceUnhealthy()
+}
+
+type serviceUnhealthy struct {
+	service string
+	reason  string
+}
+
+// NewServiceUnhealthy returns an error reporting that service is unhealthy,
+// for the given reason (e.g. "failed its healthcheck", "is in state 'restarting'").
+func NewServiceUnhealthy(service, reason string) error {
+	return &serviceUnhealthy{service: service, reason: reason}
+}
+
+func (e *serviceUnhealthy) Error() string {
+	return "service '" + e.service + "' " + e.reason
+}
+func (e *serviceUnhealthy) Service() string   { return e.service }
+func (e *serviceUnhealthy) ServiceUnhealthy() {}
+
+// AsServiceUnhealthy returns the ServiceUnhealthy in err's cause chain, if any.
+func AsServiceUnhealthy(err error) (ServiceUnhealthy, bool) {
+	for _, e := range chain(err) {
+		if su, ok := e.(ServiceUnhealthy); ok {
+			return su, true
+		}
+	}
+
+	return nil, false
+}
+
+// IsServiceUnhealthy reports whether err (or a cause of it) is a ServiceUnhealthy.
+func IsServiceUnhealthy(err error) bool {
+	_, ok := AsServiceUnhealthy(err)
+	return ok
+}
+
+// WorkdirInvalid is implemented by errors reporting that sourced's working
+// directory is missing or malformed, e.g. `sourced init` was never run.
+type WorkdirInvalid interface {
+	error
+	WorkdirInvalid()
+}
+
+type workdirInvalid struct {
+	cause error
+}
+
+// NewWorkdirInvalid wraps cause as a WorkdirInvalid error.
+func NewWorkdirInvalid(cause error) error {
+	return &workdirInvalid{cause: cause}
+}
+
+func (e *workdirInvalid) Error() string   { return e.cause.Error() }
+func (e *workdirInvalid) Cause() error    { return e.cause }
+func (e *workdirInvalid) WorkdirInvalid() {}
+
+// IsWorkdirInvalid reports whether err (or a cause of it) is a WorkdirInvalid.
+func IsWorkdirInvalid(err error) bool {
+	for _, e := range chain(err) {
+		if _, ok := e.(WorkdirInvalid); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DockerUnavailable is implemented by errors reporting that the Docker
+// daemon couldn't be reached at all, as opposed to a service failing once
+// Docker was reachable.
+type DockerUnavailable interface {
+	error
+	DockerUnavailable()
+}
+
+type dockerUnavailable struct {
+	cause error
+}
+
+// NewDockerUnavailable wraps cause as a DockerUnavailable error.
+func NewDockerUnavailable(cause error) error {
+	return &dockerUnavailable{cause: cause}
+}
+
+func (e *dockerUnavailable) Error() string      { return e.cause.Error() }
+func (e *dockerUnavailable) Cause() error       { return e.cause }
+func (e *dockerUnavailable) DockerUnavailable() {}
+
+// IsDockerUnavailable reports whether err (or a cause of it) is a DockerUnavailable.
+func IsDockerUnavailable(err error) bool {
+	for _, e := range chain(err) {
+		if _, ok := e.(DockerUnavailable); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Timeout is implemented by errors reporting that sourced gave up waiting
+// for something (a container, a healthcheck, a port) within its deadline.
+type Timeout interface {
+	error
+	Timeout()
+}
+
+type timeout struct {
+	msg string
+}
+
+// NewTimeout returns a Timeout error with the given message.
+func NewTimeout(msg string) error {
+	return &timeout{msg: msg}
+}
+
+func (e *timeout) Error() string { return e.msg }
+func (e *timeout) Timeout()      {}
+
+// IsTimeout reports whether err (or a cause of it) is a Timeout.
+func IsTimeout(err error) bool {
+	for _, e := range chain(err) {
+		if _, ok := e.(Timeout); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Exit codes sourced's rootCmd maps a returned error to, via ExitCode,
+// before calling os.Exit.
+const (
+	ExitOK                = 0
+	ExitUnknown           = 1
+	ExitServiceExited     = 10
+	ExitServiceUnhealthy  = 11
+	ExitWorkdirInvalid    = 12
+	ExitDockerUnavailable = 13
+	ExitTimeout           = 14
+)
+
+// ExitCode maps err to the process exit code rootCmd should terminate with.
+// err == nil maps to ExitOK; an err of an unrecognized kind maps to
+// ExitUnknown.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case IsServiceExited(err):
+		return ExitServiceExited
+	case IsServiceUnhealthy(err):
+		return ExitServiceUnhealthy
+	case IsWorkdirInvalid(err):
+		return ExitWorkdirInvalid
+	case IsDockerUnavailable(err):
+		return ExitDockerUnavailable
+	case IsTimeout(err):
+		return ExitTimeout
+	default:
+		return ExitUnknown
+	}
+}
@@ -0,0 +1,84 @@
+package srcderr
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestIsHelpersSeeThroughWrapping(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		is   func(error) bool
+	}{
+		{"ServiceExited", NewServiceExited("ghsync", 1), IsServiceExited},
+		{"ServiceUnhealthy", NewServiceUnhealthy("sourced-ui", "failed its healthcheck"), IsServiceUnhealthy},
+		{"WorkdirInvalid", NewWorkdirInvalid(errors.New("no docker-compose.yml")), IsWorkdirInvalid},
+		{"DockerUnavailable", NewDockerUnavailable(errors.New("no such host")), IsDockerUnavailable},
+		{"Timeout", NewTimeout("gave up waiting"), IsTimeout},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if !c.is(c.err) {
+				t.Fatalf("%s: expected is-check to match unwrapped error", c.name)
+			}
+
+			wrapped := errors.Wrap(c.err, "while doing something")
+			if !c.is(wrapped) {
+				t.Fatalf("%s: expected is-check to match wrapped error", c.name)
+			}
+		})
+	}
+}
+
+func TestIsHelpersRejectUnrelatedErrors(t *testing.T) {
+	other := errors.New("some other failure")
+
+	checks := []func(error) bool{
+		IsServiceExited, IsServiceUnhealthy, IsWorkdirInvalid, IsDockerUnavailable, IsTimeout,
+	}
+	for _, is := range checks {
+		if is(other) {
+			t.Fatalf("expected is-check to reject an unrelated error")
+		}
+	}
+}
+
+func TestAsServiceExited(t *testing.T) {
+	err := errors.Wrap(NewServiceExited("gitcollector", 2), "monitor failed")
+
+	se, ok := AsServiceExited(err)
+	if !ok {
+		t.Fatal("AsServiceExited() ok = false, want true")
+	}
+
+	if se.Service() != "gitcollector" || se.ExitCode() != 2 {
+		t.Errorf("AsServiceExited() = %+v, want service=gitcollector exitCode=2", se)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"ServiceExited", NewServiceExited("ghsync", 1), ExitServiceExited},
+		{"ServiceUnhealthy", NewServiceUnhealthy("sourced-ui", "failed its healthcheck"), ExitServiceUnhealthy},
+		{"WorkdirInvalid", NewWorkdirInvalid(errors.New("x")), ExitWorkdirInvalid},
+		{"DockerUnavailable", NewDockerUnavailable(errors.New("x")), ExitDockerUnavailable},
+		{"Timeout", NewTimeout("x"), ExitTimeout},
+		{"unknown", errors.New("something else"), ExitUnknown},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := ExitCode(c.err); got != c.want {
+				t.Errorf("ExitCode() = %d, want %d", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Package wait provides readiness primitives for blocking until a service
+// is actually reachable or healthy, instead of polling blindly with a fixed
+// timeout.
+package wait
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/src-d/sourced-ce/cmd/sourced/compose"
+	"github.com/src-d/sourced-ce/cmd/sourced/srcderr"
+)
+
+const (
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 2 * time.Second
+
+	// serviceStateTimeout bounds each individual ServiceState call
+	// AwaitHealthy makes, so a Docker daemon that hangs rather than
+	// actively erroring out can't keep this loop from ever re-checking its
+	// own deadline.
+	serviceStateTimeout = 5 * time.Second
+)
+
+// AwaitReachable blocks until a TCP connection to hostPort succeeds, or
+// returns an error once timeout elapses. It retries with exponential
+// backoff instead of busy-looping.
+func AwaitReachable(hostPort string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for backoff := minBackoff; time.Now().Before(deadline); backoff = nextBackoff(backoff) {
+		conn, err := net.DialTimeout("tcp", hostPort, backoff)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		time.Sleep(backoff)
+	}
+
+	return srcderr.NewTimeout(fmt.Sprintf("%s was not reachable after %v", hostPort, timeout))
+}
+
+// AwaitHealthy blocks until the given service's container reports a
+// "healthy" Docker healthcheck status, or returns an error once timeout
+// elapses. A service with no healthcheck declared in docker-compose.yml is
+// considered healthy as soon as it's running.
+func AwaitHealthy(engine compose.Engine, service string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for backoff := minBackoff; time.Now().Before(deadline); backoff = nextBackoff(backoff) {
+		ctx, cancel := context.WithTimeout(context.Background(), serviceStateTimeout)
+		state, err := engine.ServiceState(ctx, service)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		switch state.Health {
+		case "unhealthy":
+			return srcderr.NewServiceUnhealthy(service, "failed its healthcheck")
+		case "", "none", "healthy":
+			if state.Status == "running" {
+				return nil
+			}
+		}
+
+		time.Sleep(backoff)
+	}
+
+	return srcderr.NewTimeout(fmt.Sprintf("service '%s' was not healthy after %v", service, timeout))
+}
+
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+
+	return backoff
+}
@@ -0,0 +1,48 @@
+package wait
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/src-d/sourced-ce/cmd/sourced/srcderr"
+)
+
+func TestNextBackoff(t *testing.T) {
+	cases := []struct {
+		in   time.Duration
+		want time.Duration
+	}{
+		{100 * time.Millisecond, 200 * time.Millisecond},
+		{time.Second, 2 * time.Second},
+		{maxBackoff, maxBackoff},
+		{maxBackoff / 2 * 3, maxBackoff},
+	}
+
+	for _, c := range cases {
+		if got := nextBackoff(c.in); got != c.want {
+			t.Errorf("nextBackoff(%v) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestAwaitReachableSucceeds(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln.Close()
+
+	if err := AwaitReachable(ln.Addr().String(), time.Second); err != nil {
+		t.Fatalf("AwaitReachable() error = %v, want nil", err)
+	}
+}
+
+func TestAwaitReachableTimesOut(t *testing.T) {
+	// Port 0 never accepts connections, so every dial attempt fails until
+	// the deadline elapses.
+	err := AwaitReachable("127.0.0.1:0", 250*time.Millisecond)
+	if !srcderr.IsTimeout(err) {
+		t.Fatalf("AwaitReachable() error = %v, want a srcderr.Timeout", err)
+	}
+}